@@ -0,0 +1,218 @@
+package shlog4go
+
+import (
+	"bufio"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DropPolicy controls what EnableAsync's writer does when its buffer is
+// full and a new record arrives.
+type DropPolicy int
+
+const (
+	// DropPolicyBlock makes the calling Printf/Println wait for room in
+	// the buffer, the same back-pressure a synchronous SHLogger has today.
+	DropPolicyBlock DropPolicy = iota
+	// DropPolicyDropOldest discards the oldest buffered record to make
+	// room, so logging never blocks the caller at the cost of losing
+	// history under sustained overload.
+	DropPolicyDropOldest
+)
+
+// AsyncStats reports how an async pipeline installed by EnableAsync is
+// doing. All three counters are cumulative since EnableAsync was called.
+type AsyncStats struct {
+	Queued  uint64
+	Written uint64
+	Dropped uint64
+}
+
+// asyncWriter buffers preformatted records in a channel and writes them to
+// next in batches from a single background goroutine, so Printf/Println no
+// longer block on I/O under log.mutex.
+type asyncWriter struct {
+	next      WriteCloser
+	ch        chan []byte
+	flushReq  chan chan struct{}
+	reopenReq chan chan error
+	policy    DropPolicy
+	stats     AsyncStats
+	wg        sync.WaitGroup
+}
+
+func newAsyncWriter(next WriteCloser, bufferSize int, flushInterval time.Duration, policy DropPolicy) *asyncWriter {
+	w := &asyncWriter{
+		next:      next,
+		ch:        make(chan []byte, bufferSize),
+		flushReq:  make(chan chan struct{}),
+		reopenReq: make(chan chan error),
+		policy:    policy,
+	}
+	w.wg.Add(1)
+	go w.run(flushInterval)
+	return w
+}
+
+func (w *asyncWriter) run(flushInterval time.Duration) {
+	defer w.wg.Done()
+	bw := bufio.NewWriter(w.next)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case p, ok := <-w.ch:
+			if !ok {
+				bw.Flush()
+				return
+			}
+			if _, err := bw.Write(p); err == nil {
+				atomic.AddUint64(&w.stats.Written, 1)
+			}
+		case ack := <-w.flushReq:
+			// Write out every record queued before sync was called, not
+			// just what bw already holds, before acknowledging.
+			for drained := false; !drained; {
+				select {
+				case p, ok := <-w.ch:
+					if !ok {
+						drained = true
+					} else if _, err := bw.Write(p); err == nil {
+						atomic.AddUint64(&w.stats.Written, 1)
+					}
+				default:
+					drained = true
+				}
+			}
+			bw.Flush()
+			close(ack)
+		case req := <-w.reopenReq:
+			// Flush whatever this goroutine already buffered before the
+			// underlying file is swapped out from under it, then hand the
+			// reopen to next -- the only place that touches next, so it
+			// never races the bw.Write/bw.Flush calls above.
+			bw.Flush()
+			var err error
+			if r, ok := w.next.(reopener); ok {
+				err = r.reopen()
+			} else if f, ok := w.next.(*os.File); ok {
+				f.Close()
+				var nf *os.File
+				if nf, err = os.OpenFile(f.Name(), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644); err == nil {
+					w.next = nf
+					bw.Reset(nf)
+				}
+			}
+			req <- err
+		case <-ticker.C:
+			bw.Flush()
+		}
+	}
+}
+
+// Write implements io.Writer. It never performs I/O itself; it only hands
+// p to the background goroutine, blocking or dropping the oldest queued
+// record per w.policy when the buffer is full.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	if w.policy == DropPolicyBlock {
+		w.ch <- p
+		atomic.AddUint64(&w.stats.Queued, 1)
+		return len(p), nil
+	}
+	select {
+	case w.ch <- p:
+		atomic.AddUint64(&w.stats.Queued, 1)
+		return len(p), nil
+	default:
+	}
+	select {
+	case <-w.ch:
+		atomic.AddUint64(&w.stats.Dropped, 1)
+	default:
+	}
+	select {
+	case w.ch <- p:
+		atomic.AddUint64(&w.stats.Queued, 1)
+	default:
+		atomic.AddUint64(&w.stats.Dropped, 1)
+	}
+	return len(p), nil
+}
+
+// sync flushes any buffered records and waits for the background goroutine
+// to finish writing them.
+func (w *asyncWriter) sync() {
+	ack := make(chan struct{})
+	w.flushReq <- ack
+	<-ack
+}
+
+// Close drains and flushes the buffer, stops the background goroutine and
+// closes the wrapped writer.
+func (w *asyncWriter) Close() error {
+	close(w.ch)
+	w.wg.Wait()
+	return w.next.Close()
+}
+
+// reopen asks the background goroutine to reopen w.next (a RotateWriter in
+// place, or a plain *os.File by closing and recreating it) in its own turn of run's select loop, the same way sync
+// asks it to flush. Doing it there -- rather than calling w.next.reopen()
+// directly from whatever goroutine calls SHLogger.Reopen -- means it can
+// never race the goroutine's own bw.Write/bw.Flush calls against next.
+func (w *asyncWriter) reopen() error {
+	req := make(chan error)
+	w.reopenReq <- req
+	return <-req
+}
+
+func (w *asyncWriter) snapshot() AsyncStats {
+	return AsyncStats{
+		Queued:  atomic.LoadUint64(&w.stats.Queued),
+		Written: atomic.LoadUint64(&w.stats.Written),
+		Dropped: atomic.LoadUint64(&w.stats.Dropped),
+	}
+}
+
+// EnableAsync installs a buffered, non-blocking pipeline in front of log's
+// current writer: Printf/Println enqueue a preformatted record into a
+// channel of size bufferSize that a background goroutine drains into a
+// bufio.Writer, flushing every flushInterval. Call SetAsyncDropPolicy
+// before EnableAsync to drop the oldest record on overflow instead of
+// blocking the caller (the default).
+func (log *SHLogger) EnableAsync(bufferSize int, flushInterval time.Duration) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.out = newAsyncWriter(log.out, bufferSize, flushInterval, log.asyncDropPolicy)
+}
+
+// SetAsyncDropPolicy chooses what a subsequent EnableAsync does when its
+// buffer is full. It has no effect once EnableAsync has already run.
+func (log *SHLogger) SetAsyncDropPolicy(policy DropPolicy) {
+	log.asyncDropPolicy = policy
+}
+
+// Sync flushes any records buffered by EnableAsync and waits for them to be
+// written. It is a no-op if EnableAsync was never called.
+func (log *SHLogger) Sync() {
+	log.mutex.Lock()
+	w, ok := log.out.(*asyncWriter)
+	log.mutex.Unlock()
+	if ok {
+		w.sync()
+	}
+}
+
+// Stats reports the async pipeline's cumulative queued/written/dropped
+// counters. It returns the zero value if EnableAsync was never called.
+func (log *SHLogger) Stats() AsyncStats {
+	log.mutex.Lock()
+	w, ok := log.out.(*asyncWriter)
+	log.mutex.Unlock()
+	if !ok {
+		return AsyncStats{}
+	}
+	return w.snapshot()
+}
@@ -0,0 +1,107 @@
+package shlog4go
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// blockingWriteCloser blocks every Write until unblock is closed, letting a
+// test fill an asyncWriter's channel deterministically instead of racing the
+// background goroutine draining it. Each payload is large enough that
+// bufio.Writer (wrapped around it by asyncWriter.run) writes it straight
+// through rather than buffering it, so the block is visible immediately.
+type blockingWriteCloser struct {
+	unblock chan struct{}
+}
+
+func (w *blockingWriteCloser) Write(p []byte) (int, error) {
+	<-w.unblock
+	return len(p), nil
+}
+
+func (w *blockingWriteCloser) Close() error {
+	return nil
+}
+
+// bigPayload is larger than bufio.Writer's default buffer, so writing it
+// bypasses buffering and reaches blockingWriteCloser.Write directly.
+var bigPayload = bytes.Repeat([]byte("x"), 8192)
+
+// TestAsyncWriterDropOldestOverflow checks that DropPolicyDropOldest
+// discards buffered records to make room instead of blocking Write once the
+// channel is full.
+func TestAsyncWriterDropOldestOverflow(t *testing.T) {
+	next := &blockingWriteCloser{unblock: make(chan struct{})}
+	w := newAsyncWriter(next, 2, time.Hour, DropPolicyDropOldest)
+	defer func() {
+		close(next.unblock)
+		w.Close()
+	}()
+
+	// The first Write is picked up by run's goroutine and blocks in
+	// next.Write, so the remaining writes below all contend for the
+	// 2-slot channel behind it.
+	for i := 0; i < 10; i++ {
+		if _, err := w.Write(bigPayload); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	stats := w.snapshot()
+	if stats.Dropped == 0 {
+		t.Fatalf("expected DropPolicyDropOldest to drop records once the buffer filled, got stats=%+v", stats)
+	}
+}
+
+// TestAsyncWriterBlockPolicyNeverDrops checks that DropPolicyBlock (the
+// default) never drops a record, even when the buffer is momentarily full:
+// Write waits for room instead.
+func TestAsyncWriterBlockPolicyNeverDrops(t *testing.T) {
+	next := &blockingWriteCloser{unblock: make(chan struct{})}
+	w := newAsyncWriter(next, 1, time.Hour, DropPolicyBlock)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			w.Write(bigPayload)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("all writes returned before the blocked background writer was ever unblocked")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(next.unblock)
+	<-done
+	w.Close()
+
+	stats := w.snapshot()
+	if stats.Dropped != 0 {
+		t.Fatalf("DropPolicyBlock dropped %d records, want 0", stats.Dropped)
+	}
+	if stats.Queued != 5 {
+		t.Fatalf("Queued = %d, want 5", stats.Queued)
+	}
+}
+
+// TestAsyncWriterSyncFlushesAndWaits checks that sync blocks until the
+// background goroutine has written everything queued before it was called.
+func TestAsyncWriterSyncFlushesAndWaits(t *testing.T) {
+	next := &blockingWriteCloser{unblock: make(chan struct{})}
+	close(next.unblock)
+	w := newAsyncWriter(next, 8, time.Hour, DropPolicyBlock)
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		w.Write(bigPayload)
+	}
+	w.sync()
+
+	if got := w.snapshot().Written; got != 3 {
+		t.Fatalf("Written after sync = %d, want 3", got)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build binary_log
+
+package shlog4go
+
+import (
+	"strings"
+
+	"github.com/sunhe/shlog4go/internal/cborlog"
+)
+
+// binaryHandler serializes each record as a self-delimited CBOR map instead
+// of JSON, trading human-readability for a smaller on-disk footprint and a
+// faster encode path on services logging millions of events/day. Decode it
+// back with cmd/shlogcat.
+type binaryHandler struct{}
+
+// NewBinaryHandler returns a Handler that writes each record as CBOR, under
+// keys t (time), L (level), c (category), m (msg), caller, plus any attrs.
+// It is only available when built with the binary_log tag.
+func NewBinaryHandler() Handler {
+	return binaryHandler{}
+}
+
+func (binaryHandler) Handle(category, level, header, msg string, attrs []Attr, timeformat string) []byte {
+	rec := cborlog.Record{}
+	rec.Add("t", getTimeString(timeformat))
+	rec.Add("L", level)
+	rec.Add("c", category)
+	rec.Add("m", strings.TrimSuffix(msg, "\n"))
+	rec.Add("caller", header)
+	for _, a := range attrs {
+		rec.Add(a.Key, logfmtValue(a.Value))
+	}
+	return rec.Encode()
+}
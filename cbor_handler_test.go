@@ -0,0 +1,44 @@
+//go:build binary_log
+
+package shlog4go
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/sunhe/shlog4go/internal/cborlog"
+)
+
+// TestBinaryHandlerRoundTrip checks that a record formatted by binaryHandler
+// decodes back to the expected fields via cborlog.DecodeRecord, the same way
+// cmd/shlogcat reads a binary_log file.
+func TestBinaryHandlerRoundTrip(t *testing.T) {
+	h := NewBinaryHandler()
+	buf := h.Handle("app", "WARN", "main.go:42: ", "hello\n", []Attr{{Key: "req_id", Value: "abc123"}}, "")
+
+	rec, err := cborlog.DecodeRecord(bufio.NewReader(bytes.NewReader(buf)))
+	if err != nil {
+		t.Fatalf("DecodeRecord: %v", err)
+	}
+
+	want := map[string]string{
+		"L":      "WARN",
+		"c":      "app",
+		"m":      "hello",
+		"caller": "main.go:42: ",
+		"req_id": "abc123",
+	}
+	got := make(map[string]string, len(rec.Fields))
+	for _, f := range rec.Fields {
+		got[f.Key] = f.Value
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("field %q = %q, want %q (record=%+v)", k, got[k], v, rec)
+		}
+	}
+	if _, ok := got["t"]; !ok {
+		t.Fatalf("record missing time field: %+v", rec)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build binary_log
+
+// Command shlogcat decodes a shlog4go binary_log (CBOR) stream back into
+// human-readable text or JSON.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/sunhe/shlog4go/internal/cborlog"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "emit one JSON object per record instead of plain text")
+	flag.Parse()
+
+	in := os.Stdin
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r := bufio.NewReader(in)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	for {
+		rec, err := cborlog.DecodeRecord(r)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *asJSON {
+			writeJSON(out, rec)
+		} else {
+			writeText(out, rec)
+		}
+	}
+}
+
+func writeJSON(out *bufio.Writer, rec cborlog.Record) {
+	m := make(map[string]string, len(rec.Fields))
+	for _, f := range rec.Fields {
+		m[f.Key] = f.Value
+	}
+	enc := json.NewEncoder(out)
+	if err := enc.Encode(m); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeText(out *bufio.Writer, rec cborlog.Record) {
+	for i, f := range rec.Fields {
+		if i > 0 {
+			out.WriteByte(' ')
+		}
+		fmt.Fprintf(out, "%s=%s", f.Key, f.Value)
+	}
+	out.WriteByte('\n')
+}
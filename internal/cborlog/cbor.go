@@ -0,0 +1,157 @@
+// Package cborlog implements the minimal subset of CBOR (RFC 8949) needed
+// to read and write shlog4go's binary log records: definite-length maps of
+// text-string keys to text-string values. It has no dependency on the
+// shlog4go package so both the binary_log-tagged handler and cmd/shlogcat
+// can share it.
+package cborlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	majorUint = 0
+	majorText = 3
+	majorMap  = 5
+)
+
+// Field is one key/value pair of a Record, in encoding/decoding order.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Record is a self-delimited CBOR map: a shlog4go log entry's fixed fields
+// (t, L, c, m, caller) followed by any user attrs, all encoded as text.
+type Record struct {
+	Fields []Field
+}
+
+// Add appends a key/value pair to the record.
+func (r *Record) Add(key, value string) {
+	r.Fields = append(r.Fields, Field{Key: key, Value: value})
+}
+
+// Encode renders the record as a single CBOR map item.
+func (r *Record) Encode() []byte {
+	buf := appendUint(nil, majorMap, uint64(len(r.Fields)))
+	for _, f := range r.Fields {
+		buf = appendText(buf, f.Key)
+		buf = appendText(buf, f.Value)
+	}
+	return buf
+}
+
+func appendUint(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= 0xff:
+		return append(buf, major<<5|24, byte(n))
+	case n <= 0xffff:
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(n))
+		return append(append(buf, major<<5|25), b[:]...)
+	case n <= 0xffffffff:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(n))
+		return append(append(buf, major<<5|26), b[:]...)
+	default:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], n)
+		return append(append(buf, major<<5|27), b[:]...)
+	}
+}
+
+func appendText(buf []byte, s string) []byte {
+	buf = appendUint(buf, majorText, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// DecodeRecord reads one Record from r, returning io.EOF only when r is
+// exhausted before any bytes of a new record are read.
+func DecodeRecord(r *bufio.Reader) (Record, error) {
+	if _, err := r.Peek(1); err != nil {
+		return Record{}, err
+	}
+	fail := func(err error) (Record, error) {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Record{}, err
+	}
+	major, n, err := readHeader(r)
+	if err != nil {
+		return fail(err)
+	}
+	if major != majorMap {
+		return Record{}, fmt.Errorf("cborlog: expected map, got major type %d", major)
+	}
+	rec := Record{}
+	for i := uint64(0); i < n; i++ {
+		key, err := readText(r)
+		if err != nil {
+			return fail(err)
+		}
+		value, err := readText(r)
+		if err != nil {
+			return fail(err)
+		}
+		rec.Add(key, value)
+	}
+	return rec, nil
+}
+
+func readHeader(r *bufio.Reader) (major byte, n uint64, err error) {
+	b0, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	major = b0 >> 5
+	info := b0 & 0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		b, err := r.ReadByte()
+		return major, uint64(b), err
+	case info == 25:
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return major, 0, err
+		}
+		return major, uint64(binary.BigEndian.Uint16(b[:])), nil
+	case info == 26:
+		var b [4]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return major, 0, err
+		}
+		return major, uint64(binary.BigEndian.Uint32(b[:])), nil
+	case info == 27:
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return major, 0, err
+		}
+		return major, binary.BigEndian.Uint64(b[:]), nil
+	default:
+		return major, 0, fmt.Errorf("cborlog: unsupported additional info %d", info)
+	}
+}
+
+func readText(r *bufio.Reader) (string, error) {
+	major, n, err := readHeader(r)
+	if err != nil {
+		return "", err
+	}
+	if major != majorText {
+		return "", fmt.Errorf("cborlog: expected text string, got major type %d", major)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,75 @@
+package cborlog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	rec := Record{}
+	rec.Add("t", "2026-07-25T00:00:00Z")
+	rec.Add("L", "WARN")
+	rec.Add("c", "app")
+	rec.Add("m", "hello world")
+	rec.Add("caller", "main.go:42")
+	rec.Add("req_id", "abc123")
+
+	got, err := DecodeRecord(bufio.NewReader(bytes.NewReader(rec.Encode())))
+	if err != nil {
+		t.Fatalf("DecodeRecord: %v", err)
+	}
+	if len(got.Fields) != len(rec.Fields) {
+		t.Fatalf("got %d fields, want %d", len(got.Fields), len(rec.Fields))
+	}
+	for i, f := range rec.Fields {
+		if got.Fields[i] != f {
+			t.Fatalf("field %d = %+v, want %+v", i, got.Fields[i], f)
+		}
+	}
+}
+
+// TestDecodeRecordStream checks that multiple records written back to back
+// (as cmd/shlogcat reads them from a log file) decode in order, and that
+// DecodeRecord reports io.EOF once the stream is exhausted.
+func TestDecodeRecordStream(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < 3; i++ {
+		rec := Record{}
+		rec.Add("m", string(rune('a'+i)))
+		buf.Write(rec.Encode())
+	}
+
+	r := bufio.NewReader(&buf)
+	for i := 0; i < 3; i++ {
+		rec, err := DecodeRecord(r)
+		if err != nil {
+			t.Fatalf("DecodeRecord %d: %v", i, err)
+		}
+		want := string(rune('a' + i))
+		if len(rec.Fields) != 1 || rec.Fields[0].Value != want {
+			t.Fatalf("record %d = %+v, want m=%q", i, rec, want)
+		}
+	}
+
+	if _, err := DecodeRecord(r); err != io.EOF {
+		t.Fatalf("DecodeRecord at end of stream = %v, want io.EOF", err)
+	}
+}
+
+// TestEncodeLargeField exercises the multi-byte length prefixes in
+// appendUint/appendText, not just the single-byte fast path.
+func TestEncodeLargeField(t *testing.T) {
+	big := bytes.Repeat([]byte("x"), 300)
+	rec := Record{}
+	rec.Add("msg", string(big))
+
+	got, err := DecodeRecord(bufio.NewReader(bytes.NewReader(rec.Encode())))
+	if err != nil {
+		t.Fatalf("DecodeRecord: %v", err)
+	}
+	if len(got.Fields) != 1 || got.Fields[0].Value != string(big) {
+		t.Fatalf("round-tripped field length = %d, want %d", len(got.Fields[0].Value), len(big))
+	}
+}
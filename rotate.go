@@ -0,0 +1,230 @@
+package shlog4go
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteCloser is the abstraction SHLogger writes log records to. *os.File
+// satisfies it directly; RotateWriter is the other built-in implementation.
+type WriteCloser interface {
+	io.Writer
+	io.Closer
+}
+
+// reopener is implemented by writers that need to re-acquire their
+// underlying file on SIGHUP (logrotate moved/removed it) without going
+// through a size/age/backup rotation. SHLogger.Reopen uses it when present.
+type reopener interface {
+	reopen() error
+}
+
+const (
+	// FormatTimeDay rotates the active file once per day, suffixing
+	// rotated files with their date, e.g. app.log.20060102.
+	FormatTimeDay = "20060102"
+	// FormatTimeHour rotates the active file once per hour, suffixing
+	// rotated files with their date and hour, e.g. app.log.2006010215.
+	FormatTimeHour = "2006010215"
+)
+
+// RotateWriter is a WriteCloser that rotates the underlying file by size
+// and/or time, keeping at most MaxBackups old files (optionally gzipped)
+// and deleting anything older than MaxAge.
+type RotateWriter struct {
+	// Filename is the base path Printf/Println writes to; rotated files
+	// are renamed to Filename plus a timestamp (and .gz when Compress).
+	Filename string
+	// MaxSizeMB rotates the file once it grows past this many megabytes.
+	// Zero disables size-based rotation.
+	MaxSizeMB int64
+	// MaxAge removes rotated files older than this duration. Zero keeps
+	// them forever (subject to MaxBackups).
+	MaxAge time.Duration
+	// MaxBackups caps the number of rotated files kept; the oldest are
+	// removed first. Zero keeps them all.
+	MaxBackups int
+	// Compress gzips rotated files as they're created.
+	Compress bool
+	// TimeFormat, when non-empty, rotates on every change of
+	// time.Now().Format(TimeFormat) -- FormatTimeDay or FormatTimeHour are
+	// the common cases, but any layout works.
+	TimeFormat string
+
+	file      *os.File
+	size      int64
+	curSuffix string
+}
+
+// NewRotateWriter opens filename (creating it if necessary) and returns a
+// RotateWriter ready to be installed on an SHLogger via SetWriter.
+func NewRotateWriter(filename string) (*RotateWriter, error) {
+	w := &RotateWriter{Filename: filename}
+	if err := w.openCurrent(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotateWriter) openCurrent() error {
+	f, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	if w.TimeFormat != "" {
+		w.curSuffix = time.Now().Format(w.TimeFormat)
+	}
+	return nil
+}
+
+// Write implements io.Writer. Callers reach it through SHLogger.out, either
+// under log.mutex or, once EnableAsync is active, only from the async
+// pipeline's single background goroutine, so the rotation performed here
+// never races a concurrent Printf/Println.
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	if w.needsRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotateWriter) needsRotate(nextWrite int) bool {
+	if w.MaxSizeMB > 0 && w.size+int64(nextWrite) > w.MaxSizeMB*1024*1024 {
+		return true
+	}
+	if w.TimeFormat != "" && time.Now().Format(w.TimeFormat) != w.curSuffix {
+		return true
+	}
+	return false
+}
+
+func (w *RotateWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+	}
+	suffix := w.curSuffix
+	if suffix == "" {
+		suffix = time.Now().Format("20060102150405")
+	}
+	rotated := fmt.Sprintf("%s.%s", w.Filename, suffix)
+	if _, err := os.Stat(w.Filename); err == nil {
+		if err := os.Rename(w.Filename, rotated); err != nil {
+			return err
+		}
+		if w.Compress {
+			if err := gzipFile(rotated); err == nil {
+				os.Remove(rotated)
+			}
+		}
+	}
+	w.pruneBackups()
+	return w.openCurrent()
+}
+
+func gzipFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	if _, err := gw.Write(raw); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// pruneBackups removes rotated files beyond MaxBackups and/or older than
+// MaxAge. Failures to stat or remove an individual backup are ignored so a
+// single bad file can't stop logging.
+func (w *RotateWriter) pruneBackups() {
+	if w.MaxBackups <= 0 && w.MaxAge <= 0 {
+		return
+	}
+	dir := filepath.Dir(w.Filename)
+	base := filepath.Base(w.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var backups []os.FileInfo
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == base {
+			continue
+		}
+		if strings.HasPrefix(e.Name(), base+".") {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			backups = append(backups, info)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime().Before(backups[j].ModTime())
+	})
+	if w.MaxAge > 0 {
+		cutoff := time.Now().Add(-w.MaxAge)
+		kept := backups[:0]
+		for _, b := range backups {
+			if b.ModTime().Before(cutoff) {
+				os.Remove(filepath.Join(dir, b.Name()))
+				continue
+			}
+			kept = append(kept, b)
+		}
+		backups = kept
+	}
+	if w.MaxBackups > 0 && len(backups) > w.MaxBackups {
+		for _, b := range backups[:len(backups)-w.MaxBackups] {
+			os.Remove(filepath.Join(dir, b.Name()))
+		}
+	}
+}
+
+// Close implements io.Closer.
+func (w *RotateWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// reopen re-acquires Filename without rotating, for the SIGHUP case where
+// an external tool (logrotate) has already moved the file aside.
+func (w *RotateWriter) reopen() error {
+	if w.file != nil {
+		w.file.Close()
+	}
+	return w.openCurrent()
+}
+
+// SetWriter installs w as the destination for Printf/Println, replacing
+// whatever Open set up. Use it with NewRotateWriter to enable rotation.
+func (log *SHLogger) SetWriter(w WriteCloser) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.out = w
+}
@@ -10,14 +10,19 @@ import (
 )
 
 type SHLogger struct {
-	filename   string
-	mutex      sync.Mutex
-	prefix     string
-	timeformat string
-	out        *os.File
-	categories map[string]int
-	levelmap   map[string]int
-	deflevel   int
+	filename        string
+	mutex           sync.Mutex
+	prefix          string
+	timeformat      string
+	out             WriteCloser
+	categories      map[string]int
+	levelmap        map[string]int
+	deflevel        int
+	handler         Handler
+	vmodule         []vmoduleEntry
+	backtraceAt     []backtracePoint
+	asyncDropPolicy DropPolicy
+	sinks           []sinkEntry
 }
 
 func Open(filename string) (log *SHLogger, err error) {
@@ -47,9 +52,16 @@ func (log *SHLogger) Close() {
 	log.out.Close()
 }
 
+// Reopen re-acquires the log destination, for use after a SIGHUP once an
+// external tool (logrotate, etc.) has moved filename aside. If out is a
+// rotation-aware writer it reopens in place; otherwise Reopen falls back to
+// closing and recreating the plain file.
 func (log *SHLogger) Reopen() error {
 	log.mutex.Lock()
 	defer log.mutex.Unlock()
+	if r, ok := log.out.(reopener); ok {
+		return r.reopen()
+	}
 	log.Close()
 	out, err := os.OpenFile(log.filename, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
 	if err != nil {
@@ -91,9 +103,19 @@ func getTimeString(timeformat string) string {
 	return now.Format(timeformat)
 }
 
-func (log *SHLogger) formatHeader(category string, level string, buf *[]byte) {
-	pc, file, line, _ := runtime.Caller(2)
-	f := runtime.FuncForPC(pc)
+// captureCaller looks up the pc/file/line/function of whichever code called
+// the exported logging method that invoked captureCaller (two frames up),
+// so formatHeader, checkPrintable and the backtrace-at check can all see
+// the same call site without repeating the runtime.Caller lookup.
+func (log *SHLogger) captureCaller() (pc uintptr, file string, line int, funcName string) {
+	pc, file, line, _ = runtime.Caller(2)
+	if f := runtime.FuncForPC(pc); f != nil {
+		funcName = f.Name()
+	}
+	return
+}
+
+func (log *SHLogger) formatHeader(category string, level string, pc uintptr, file string, line int, funcName string, buf *[]byte) {
 	end := len(log.prefix)
 	for i := 0; i < end; {
 		lasti := i
@@ -117,7 +139,7 @@ func (log *SHLogger) formatHeader(category string, level string, buf *[]byte) {
 		case 'l':
 			*buf = append(*buf, fmt.Sprintf("%d", line)...)
 		case 'm':
-			*buf = append(*buf, f.Name()...)
+			*buf = append(*buf, funcName...)
 		case 't':
 			*buf = append(*buf, getTimeString(log.timeformat)...)
 		case 'c':
@@ -132,8 +154,15 @@ func (log *SHLogger) formatHeader(category string, level string, buf *[]byte) {
 	}
 }
 
-func (log *SHLogger) checkPrintable(category string, level string) bool {
+// checkPrintable decides whether a record at level/category from file
+// should be emitted. A vmodule override for file wins if one matches
+// (see SetVModule); otherwise it falls back to the per-category level set
+// by SetCategory, and finally the logger's deflevel.
+func (log *SHLogger) checkPrintable(category string, level string, file string) bool {
 	lv := log.levelmap[level]
+	if vl, ok := log.vmoduleLevel(file); ok {
+		return lv <= vl
+	}
 	cl, ok := log.categories[category]
 	if ok {
 		return lv <= cl
@@ -143,45 +172,72 @@ func (log *SHLogger) checkPrintable(category string, level string) bool {
 }
 
 func (log *SHLogger) Printf(category string, level string, format string, a ...interface{}) (n int, err error) {
-	if !log.checkPrintable(category, level) {
+	pc, file, line, funcName := log.captureCaller()
+	if !log.checkPrintable(category, level, file) {
 		return
 	}
 	var buf []byte
-	log.formatHeader(category, level, &buf)
+	log.formatHeader(category, level, pc, file, line, funcName, &buf)
 	buf = append(buf, fmt.Sprintf(format, a...)...)
-	log.mutex.Lock()
-	defer log.mutex.Unlock()
-	return log.out.Write(buf)
+	if log.isBacktraceAt(file, line) {
+		appendBacktrace(&buf)
+	}
+	return log.write(buf, level)
 }
 
 func (log *SHLogger) Println(category string, level string, a ...interface{}) (n int, err error) {
-	if !log.checkPrintable(category, level) {
+	pc, file, line, funcName := log.captureCaller()
+	if !log.checkPrintable(category, level, file) {
 		return
 	}
 	var buf []byte
-	log.formatHeader(category, level, &buf)
+	log.formatHeader(category, level, pc, file, line, funcName, &buf)
 	buf = append(buf, fmt.Sprintln(a...)...)
+	if log.isBacktraceAt(file, line) {
+		appendBacktrace(&buf)
+	}
+	return log.write(buf, level)
+}
+
+// write sends buf to log.out and fans it out to any sinks registered with
+// AddSink. An async writer installed by EnableAsync is already safe for
+// concurrent callers (it only hands buf to a channel), so it is written to
+// directly; any other writer is still serialized through log.mutex as it
+// always has been.
+func (log *SHLogger) write(buf []byte, level string) (int, error) {
+	log.fanOut(buf, level)
+	if w, ok := log.out.(*asyncWriter); ok {
+		return w.Write(buf)
+	}
 	log.mutex.Lock()
 	defer log.mutex.Unlock()
 	return log.out.Write(buf)
 }
 
 func (log *SHLogger) Sprintf(category string, level string, format string, a ...interface{}) string {
-	if !log.checkPrintable(category, level) {
+	pc, file, line, funcName := log.captureCaller()
+	if !log.checkPrintable(category, level, file) {
 		return ""
 	}
 	var buf []byte
-	log.formatHeader(category, level, &buf)
+	log.formatHeader(category, level, pc, file, line, funcName, &buf)
 	buf = append(buf, fmt.Sprintf(format, a...)...)
+	if log.isBacktraceAt(file, line) {
+		appendBacktrace(&buf)
+	}
 	return string(buf)
 }
 
 func (log *SHLogger) Sprintln(category string, level string, a ...interface{}) string {
-	if !log.checkPrintable(category, level) {
+	pc, file, line, funcName := log.captureCaller()
+	if !log.checkPrintable(category, level, file) {
 		return ""
 	}
 	var buf []byte
-	log.formatHeader(category, level, &buf)
+	log.formatHeader(category, level, pc, file, line, funcName, &buf)
 	buf = append(buf, fmt.Sprintln(a...)...)
+	if log.isBacktraceAt(file, line) {
+		appendBacktrace(&buf)
+	}
 	return string(buf)
 }
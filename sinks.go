@@ -0,0 +1,150 @@
+package shlog4go
+
+import (
+	"log/syslog"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink is an additional destination a formatted record can fan out to,
+// installed with AddSink alongside the logger's primary writer. It sees
+// exactly the bytes formatHeader (and, for With, the active Handler)
+// produced, so text, JSON and logfmt output all fan out unchanged.
+type Sink interface {
+	Write(p []byte) (n int, err error)
+}
+
+// sinkEntry pairs a named Sink with the minimum level (as an int from the
+// logger's levelmap) it should receive.
+type sinkEntry struct {
+	name     string
+	sink     Sink
+	minLevel int
+}
+
+// AddSink registers s under name, receiving every record at minLevel or
+// more severe (by the logger's levelmap) in addition to whatever log.out
+// already writes. Sinks are consulted in the order they were added.
+func (log *SHLogger) AddSink(name string, s Sink, minLevel string) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.sinks = append(log.sinks, sinkEntry{name: name, sink: s, minLevel: log.levelmap[minLevel]})
+}
+
+// RemoveSink unregisters the sink added under name, closing it if it
+// implements io.Closer. It is a no-op if name isn't registered.
+func (log *SHLogger) RemoveSink(name string) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	for i, e := range log.sinks {
+		if e.name != name {
+			continue
+		}
+		if c, ok := e.sink.(interface{ Close() error }); ok {
+			c.Close()
+		}
+		log.sinks = append(log.sinks[:i], log.sinks[i+1:]...)
+		return
+	}
+}
+
+// fanOut hands buf to every registered sink whose minLevel admits level.
+func (log *SHLogger) fanOut(buf []byte, level string) {
+	lv := log.levelmap[level]
+	log.mutex.Lock()
+	sinks := log.sinks
+	log.mutex.Unlock()
+	for _, e := range sinks {
+		if lv <= e.minLevel {
+			e.sink.Write(buf)
+		}
+	}
+}
+
+// NewFileSink opens (creating if necessary) filename as an additional
+// append-only destination.
+func NewFileSink(filename string) (Sink, error) {
+	return os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// NewSyslogSink dials the local or remote syslog daemon (network/addr as
+// accepted by syslog.Dial; network == "" dials the local syslog) and
+// returns it as a Sink tagged with tag at priority.
+func NewSyslogSink(network, addr, tag string, priority syslog.Priority) (Sink, error) {
+	return syslog.Dial(network, addr, priority, tag)
+}
+
+// netSink writes each record to a TCP or UDP connection, in the
+// newline-delimited line protocol Graphite/Fluentd collectors expect,
+// enforcing writeTimeout per write so a stalled collector can't block
+// application logging (the carbon-relay-ng pattern).
+type netSink struct {
+	conn         net.Conn
+	writeTimeout time.Duration
+}
+
+// NewTCPSink dials addr over TCP and returns a Sink that applies
+// writeTimeout to every write.
+func NewTCPSink(addr string, writeTimeout time.Duration) (Sink, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &netSink{conn: conn, writeTimeout: writeTimeout}, nil
+}
+
+// NewUDPSink dials addr over UDP and returns a Sink that applies
+// writeTimeout to every write.
+func NewUDPSink(addr string, writeTimeout time.Duration) (Sink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &netSink{conn: conn, writeTimeout: writeTimeout}, nil
+}
+
+func (s *netSink) Write(p []byte) (int, error) {
+	if s.writeTimeout > 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+	return s.conn.Write(p)
+}
+
+func (s *netSink) Close() error {
+	return s.conn.Close()
+}
+
+// MemorySink is an in-memory ring buffer Sink for tests: it keeps the last
+// capacity records and never touches disk or the network.
+type MemorySink struct {
+	mutex    sync.Mutex
+	capacity int
+	records  [][]byte
+}
+
+// NewMemorySink returns a MemorySink retaining at most capacity records.
+func NewMemorySink(capacity int) *MemorySink {
+	return &MemorySink{capacity: capacity}
+}
+
+func (s *MemorySink) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	cp := append([]byte(nil), p...)
+	s.records = append(s.records, cp)
+	if len(s.records) > s.capacity {
+		s.records = s.records[len(s.records)-s.capacity:]
+	}
+	return len(p), nil
+}
+
+// Records returns a copy of the currently buffered records, oldest first.
+func (s *MemorySink) Records() [][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	out := make([][]byte, len(s.records))
+	copy(out, s.records)
+	return out
+}
@@ -0,0 +1,77 @@
+package shlog4go
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestSinkFanOutFiltersByLevel checks that AddSink's minLevel actually gates
+// which records a sink sees, and that different sinks can be configured with
+// different thresholds.
+func TestSinkFanOutFiltersByLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer log.Close()
+	log.SetDefaultLevel("ALL")
+
+	warnAndAbove := NewMemorySink(10)
+	everything := NewMemorySink(10)
+	log.AddSink("warn", warnAndAbove, "WARN")
+	log.AddSink("all", everything, "ALL")
+
+	log.Printf("app", "DEBUG", "debug line\n")
+	log.Printf("app", "WARN", "warn line\n")
+	log.Printf("app", "ERROR", "error line\n")
+
+	if got := len(warnAndAbove.Records()); got != 2 {
+		t.Fatalf("warn sink got %d records, want 2 (WARN+ERROR only)", got)
+	}
+	if got := len(everything.Records()); got != 3 {
+		t.Fatalf("all sink got %d records, want 3", got)
+	}
+}
+
+// TestRemoveSinkStopsDelivery checks that once a sink is removed, it no
+// longer receives records.
+func TestRemoveSinkStopsDelivery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer log.Close()
+	log.SetDefaultLevel("ALL")
+
+	sink := NewMemorySink(10)
+	log.AddSink("mem", sink, "ALL")
+	log.Printf("app", "WARN", "before remove\n")
+
+	log.RemoveSink("mem")
+	log.Printf("app", "WARN", "after remove\n")
+
+	if got := len(sink.Records()); got != 1 {
+		t.Fatalf("sink got %d records after RemoveSink, want 1 (only the pre-removal write)", got)
+	}
+}
+
+// TestMemorySinkRingBuffer checks that MemorySink retains only the most
+// recent capacity records.
+func TestMemorySinkRingBuffer(t *testing.T) {
+	sink := NewMemorySink(3)
+	for i := 0; i < 5; i++ {
+		sink.Write([]byte{byte('a' + i)})
+	}
+	records := sink.Records()
+	if len(records) != 3 {
+		t.Fatalf("len(Records()) = %d, want 3", len(records))
+	}
+	want := "cde"
+	for i, r := range records {
+		if string(r) != string(want[i]) {
+			t.Fatalf("Records()[%d] = %q, want %q", i, r, want[i])
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package shlog4go
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// vmoduleEntry is one "pattern=level" clause of a vmodule spec.
+type vmoduleEntry struct {
+	pattern string
+	level   int
+}
+
+// backtracePoint is one "file:line" clause of a backtrace-at spec.
+type backtracePoint struct {
+	file string
+	line int
+}
+
+// SetVModule installs glog-style per-file verbosity overrides, e.g.
+// "server.go=6,rpc/*=4,pkg/db/*=3". Each clause is a glob matched against
+// the caller's file path -- either the short filename or a suffix of the
+// full path, so "rpc/*" matches any file directly under an "rpc" directory
+// -- paired with the level that applies when it matches. checkPrintable
+// consults these overrides before falling back to categories/deflevel.
+//
+// Malformed clauses (missing "=", non-numeric level) are skipped.
+func (log *SHLogger) SetVModule(spec string) {
+	var entries []vmoduleEntry
+	for _, clause := range strings.Split(spec, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		eq := strings.LastIndex(clause, "=")
+		if eq < 0 {
+			continue
+		}
+		level, err := strconv.Atoi(strings.TrimSpace(clause[eq+1:]))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, vmoduleEntry{
+			pattern: strings.TrimSpace(clause[:eq]),
+			level:   level,
+		})
+	}
+	log.vmodule = entries
+}
+
+// SetBacktraceAt installs a set of "file:line" points that force a stack
+// dump to be appended to the record when a Printf/Println call originates
+// from exactly that location, mirroring glog's -log_backtrace_at.
+func (log *SHLogger) SetBacktraceAt(spec string) {
+	var points []backtracePoint
+	for _, clause := range strings.Split(spec, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		colon := strings.LastIndex(clause, ":")
+		if colon < 0 {
+			continue
+		}
+		line, err := strconv.Atoi(strings.TrimSpace(clause[colon+1:]))
+		if err != nil {
+			continue
+		}
+		points = append(points, backtracePoint{
+			file: strings.TrimSpace(clause[:colon]),
+			line: line,
+		})
+	}
+	log.backtraceAt = points
+}
+
+// vmoduleLevel returns the overriding level for file, if any vmodule
+// pattern matches it. Entries are consulted in the order SetVModule parsed
+// them; the first match wins.
+func (log *SHLogger) vmoduleLevel(file string) (int, bool) {
+	for _, e := range log.vmodule {
+		if vmodulePatternMatches(e.pattern, file) {
+			return e.level, true
+		}
+	}
+	return 0, false
+}
+
+// vmodulePatternMatches matches pattern against either file's short name
+// (no directories) or the trailing path segments of file with the same
+// depth as pattern, so "server.go" matches the basename anywhere and
+// "rpc/*" matches any "rpc/<name>.go" regardless of its parent directories.
+func vmodulePatternMatches(pattern, file string) bool {
+	file = filepath.ToSlash(file)
+	patSegs := strings.Split(pattern, "/")
+	if len(patSegs) == 1 {
+		ok, _ := filepath.Match(pattern, getShortFileName(file))
+		return ok
+	}
+	fileSegs := strings.Split(file, "/")
+	if len(patSegs) > len(fileSegs) {
+		return false
+	}
+	tail := strings.Join(fileSegs[len(fileSegs)-len(patSegs):], "/")
+	ok, _ := filepath.Match(pattern, tail)
+	return ok
+}
+
+// isBacktraceAt reports whether file:line is one of the points installed
+// by SetBacktraceAt, checked against both the full and short file name.
+func (log *SHLogger) isBacktraceAt(file string, line int) bool {
+	short := getShortFileName(file)
+	for _, p := range log.backtraceAt {
+		if p.line != line {
+			continue
+		}
+		if p.file == file || p.file == short {
+			return true
+		}
+	}
+	return false
+}
+
+// appendBacktrace appends a stack dump to buf, for use when isBacktraceAt
+// matches the current call site.
+func appendBacktrace(buf *[]byte) {
+	*buf = append(*buf, fmt.Sprintf("backtrace at %s:\n", getTimeString(""))...)
+	*buf = append(*buf, debug.Stack()...)
+}